@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"time"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// maxRecordFPS caps how often frames are captured while recording, so
+// a fast-rendering scene doesn't flood the disk.
+const maxRecordFPS = 30
+
+var recording bool
+var recordSeq int
+var recordAccum time.Duration
+
+// pendingScreenshot is the path to write the next rendered frame to,
+// set by the screenshot command and consumed synchronously by refresh
+// before the buffer swap. Empty when no screenshot is pending.
+var pendingScreenshot string
+
+// pendingSnapshot is the path to write the next rendered frame to, set
+// by the P keybind and consumed by refresh before the buffer swap via
+// the asynchronous capture queue. Empty when no snapshot is pending.
+var pendingSnapshot string
+
+var screenshotQueue = make(chan string, 16)
+var captureQueue = make(chan captureJob, 4)
+
+type captureJob struct {
+	img  *image.RGBA
+	path string
+}
+
+func init() {
+	go captureWorker()
+}
+
+// captureWorker writes queued frames to disk off the render thread, so
+// the frame rate isn't stalled by disk I/O.
+func captureWorker() {
+	for job := range captureQueue {
+		if err := writePNG(job.path, job.img); err != nil {
+			log.Printf("capture: %s", err)
+		}
+	}
+}
+
+// grabFrame reads the current framebuffer of win into an *image.RGBA,
+// flipping it vertically since GL's origin is bottom-left.
+func grabFrame(win *sdl.Window) *image.RGBA {
+	w, h := win.GetSize()
+	img := image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+	gl.ReadPixels(0, 0, w, h, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+
+	stride := img.Stride
+	flipped := make([]byte, len(img.Pix))
+	for row := 0; row < int(h); row++ {
+		src := img.Pix[row*stride : row*stride+stride]
+		dst := flipped[(int(h)-1-row)*stride:]
+		copy(dst, src)
+	}
+	img.Pix = flipped
+
+	return img
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+// queueCapture grabs the current framebuffer and hands it to
+// captureWorker for asynchronous encoding, used by the P screenshot
+// keybind and R recording toggle.
+func queueCapture(win *sdl.Window, path string) {
+	img := grabFrame(win)
+	select {
+	case captureQueue <- captureJob{img, path}:
+	default:
+		log.Printf("capture: queue full, dropping %s", path)
+	}
+}
+
+// captureSync grabs the current framebuffer and writes it to path
+// immediately on the render thread, bypassing captureWorker, for the
+// scene-driven screenshot command. The command itself only queues the
+// path and returns; it does not wait for the file to be written.
+func captureSync(win *sdl.Window, path string) {
+	if err := writePNG(path, grabFrame(win)); err != nil {
+		log.Printf("screenshot: %s", err)
+	}
+}
+
+func screenshotPath() string {
+	return fmt.Sprintf("vis3-%d.png", time.Now().UnixNano())
+}
+
+func recordFramePath() string {
+	path := fmt.Sprintf("vis3-record-%06d.png", recordSeq)
+	recordSeq++
+	return path
+}
@@ -40,6 +40,56 @@ COMMANDS
 	poly X Y Z [X Y Z ...]
 		places in the scene a convex polygon with any number
 		of points
+	pushmatrix
+		saves the current transform
+	popmatrix
+		restores the transform saved by the last pushmatrix
+	translate X Y Z
+		translates the current transform
+	rotate ANGLE AX AY AZ
+		rotates the current transform by ANGLE degrees about
+		the axis AX AY AZ
+	scale SX SY SZ
+		scales the current transform
+	plane NX NY NZ DIST
+		places in the scene a grid spanning the plane whose
+		points P satisfy NORM.P = DIST, clipped to the -1..1
+		view cube
+	bbox MINX MINY MINZ MAXX MAXY MAXZ
+		places in the scene a wireframe box between the two
+		given corners
+	fit
+		adjusts the view so the whole scene is visible
+	obj "PATH" [fit]
+		loads the Wavefront OBJ mesh at PATH into the scene; if
+		fit is given, the mesh is centered and rescaled into the
+		-1..1 cube
+	light INDEX X Y Z R G B
+		configures point light INDEX (0 to 7) with the given
+		position and diffuse/specular color
+	material AMBR AMBG AMBB DIFR DIFG DIFB SPCR SPCG SPCB SHIN
+		sets the ambient, diffuse and specular color and the
+		shininess used to light subsequent poly/obj faces
+	normal NX NY NZ
+		sets the current vertex normal used by subsequent
+		poly/obj faces; if never set, faces are lit with a
+		normal computed from their own vertices
+	lighting on|off
+		enables or disables lighting; lighting is off by
+		default, so existing scenes render unchanged
+	screenshot "PATH"
+		writes the next rendered frame to PATH as a PNG; the
+		file is written on the render thread before the frame
+		is shown, and reading of further commands does not
+		wait for it
+
+CONTROLS
+	The camera is a free-fly Euler camera. Moving the mouse looks
+	around; WASD translates along the view axes, Q and E move down
+	and up, Z and C roll the view, and holding shift boosts movement
+	speed. The scroll wheel, or +/-, zooms the orthographic view in
+	and out. F fits the view to the scene. P takes a screenshot, and
+	R toggles recording a PNG frame sequence.
 */
 
 //go:generate goyacc vis3.y
@@ -64,21 +114,38 @@ import (
 var lock sync.Mutex
 var cmds []cmd
 var viewPos Vec3
-var viewAngles Vec3
+var viewAngles Vec3 // yaw, pitch
+var viewRoll float32
+var orthoScale float32 = 1
 var color Vec4
 var wireColor = Vec4{1, 1, 1, 0.75}
 var clearColor Vec4
 var pointSize float32
 var thickness float32
+var curNormal Vec3
+var curNormalSet bool
 var timeDelta time.Duration
 var dirty = make(chan int, 1)
 
+var displayList uint32
+var displayListDirty = true
+var immediate bool
+
+const (
+	moveSpeed  = 1.0 // units per second
+	boostScale = 4.0
+	zoomSpeed  = 1.0
+	rollSpeed  = 60.0 // degrees per second
+)
+
 func init() {
 	runtime.LockOSThread()
 }
 
 func main() {
 	log.SetFlags(0)
+	flag.BoolVar(&immediate, "immediate", false,
+		"render in immediate mode instead of compiling a display list, for debugging")
 	flag.Parse()
 
 	yyErrorVerbose = true
@@ -150,18 +217,52 @@ func main() {
 				viewAngles[1] = float32(math.Min(float64(viewAngles[1]), 89.9999))
 				viewAngles[1] = float32(math.Max(float64(viewAngles[1]), -89.9999))
 				needRefresh()
+			case *sdl.MouseWheelEvent:
+				zoom(float32(t.Y) * zoomSpeed / 10)
+				needRefresh()
 			case *sdl.KeyDownEvent:
 				switch {
 				case t.Keysym.Sym == sdl.K_F4 && t.Keysym.Mod&sdl.KMOD_ALT != 0:
 					fallthrough
 				case t.Keysym.Sym == sdl.K_ESCAPE:
 					running = false
+				case t.Keysym.Sym == sdl.K_f:
+					lock.Lock()
+					fitScene()
+					lock.Unlock()
+					needRefresh()
+				case t.Keysym.Sym == sdl.K_p:
+					pendingSnapshot = screenshotPath()
+					needRefresh()
+				case t.Keysym.Sym == sdl.K_r:
+					recording = !recording
+					recordSeq = 0
+					if recording {
+						// Capture frame 0 on the very next
+						// refresh, instead of waiting out a
+						// full rate-cap interval.
+						recordAccum = time.Second / maxRecordFPS
+					} else {
+						recordAccum = 0
+					}
+					needRefresh()
 				default:
 					needRefresh()
 				}
 			}
 		}
 
+		if updateCamera(timeDelta) {
+			needRefresh()
+		}
+
+		select {
+		case path := <-screenshotQueue:
+			pendingScreenshot = path
+			refresh(win)
+		default:
+		}
+
 		select {
 		case <-dirty:
 			refresh(win)
@@ -177,6 +278,8 @@ func clearScene() {
 	lock.Lock()
 	defer lock.Unlock()
 	cmds = make([]cmd, 0, 1024)
+	curNormal = Vec3{}
+	curNormalSet = false
 
 	addCmd("bgcolor", []float32{0, 0, 0, 1})
 	addCmd("pointsize", []float32{6})
@@ -200,6 +303,86 @@ func needRefresh() {
 	}
 }
 
+// cameraAxes returns the camera's forward, right and up axes in world
+// space for the current viewAngles and viewRoll.
+func cameraAxes() (fwd, right, up Vec3) {
+	yaw := float64(viewAngles[0]) * math.Pi / 180
+	pitch := float64(viewAngles[1]) * math.Pi / 180
+	roll := float64(viewRoll) * math.Pi / 180
+	fwd = Vec3{
+		float32(math.Sin(yaw) * math.Cos(pitch)),
+		float32(-math.Sin(pitch)),
+		float32(-math.Cos(yaw) * math.Cos(pitch)),
+	}
+	r0 := Vec3{float32(math.Cos(yaw)), 0, float32(math.Sin(yaw))}
+	u0 := r0.Cross(fwd)
+	sr, cr := float32(math.Sin(roll)), float32(math.Cos(roll))
+	right = r0.Mul(cr).Sub(u0.Mul(sr))
+	up = r0.Mul(sr).Add(u0.Mul(cr))
+	return
+}
+
+// updateCamera applies WASD/QE translation and +/- zoom for the held
+// keys, scaled by dt for framerate-independent motion. It reports
+// whether the camera moved.
+func updateCamera(dt time.Duration) bool {
+	keys := sdl.GetKeyboardState()
+	speed := float32(moveSpeed) * float32(dt.Seconds())
+	if keys[sdl.SCANCODE_LSHIFT] != 0 || keys[sdl.SCANCODE_RSHIFT] != 0 {
+		speed *= boostScale
+	}
+
+	fwd, right, up := cameraAxes()
+	moved := false
+	move := func(axis Vec3, amt float32) {
+		viewPos = viewPos.Sub(axis.Mul(amt))
+		moved = true
+	}
+	if keys[sdl.SCANCODE_W] != 0 {
+		move(fwd, speed)
+	}
+	if keys[sdl.SCANCODE_S] != 0 {
+		move(fwd, -speed)
+	}
+	if keys[sdl.SCANCODE_D] != 0 {
+		move(right, speed)
+	}
+	if keys[sdl.SCANCODE_A] != 0 {
+		move(right, -speed)
+	}
+	if keys[sdl.SCANCODE_E] != 0 {
+		move(up, speed)
+	}
+	if keys[sdl.SCANCODE_Q] != 0 {
+		move(up, -speed)
+	}
+	roll := rollSpeed * float32(dt.Seconds())
+	if keys[sdl.SCANCODE_Z] != 0 {
+		viewRoll -= roll
+		moved = true
+	}
+	if keys[sdl.SCANCODE_C] != 0 {
+		viewRoll += roll
+		moved = true
+	}
+	if keys[sdl.SCANCODE_EQUALS] != 0 {
+		zoom(zoomSpeed * float32(dt.Seconds()))
+		moved = true
+	}
+	if keys[sdl.SCANCODE_MINUS] != 0 {
+		zoom(-zoomSpeed * float32(dt.Seconds()))
+		moved = true
+	}
+	return moved
+}
+
+// zoom adjusts the orthographic view scale by delta, clamped to a
+// sane minimum so the view can't invert.
+func zoom(delta float32) {
+	orthoScale -= delta
+	orthoScale = float32(math.Max(float64(orthoScale), 0.01))
+}
+
 func setupGL() {
 	gl.Hint(gl.LINE_SMOOTH_HINT, gl.NICEST)
 	gl.Enable(gl.LINE_SMOOTH)
@@ -212,17 +395,18 @@ func setupGL() {
 	gl.Enable(gl.BLEND)
 	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
-	gl.MatrixMode(gl.PROJECTION)
-	gl.LoadIdentity()
-	gl.Ortho(-1, 1, -1, 1, -100, 100)
-
 	gl.MatrixMode(gl.MODELVIEW)
 	gl.LoadIdentity()
 }
 
 func refresh(win *sdl.Window) {
+	gl.MatrixMode(gl.PROJECTION)
+	gl.LoadIdentity()
+	gl.Ortho(-orthoScale, orthoScale, -orthoScale, orthoScale, -100, 100)
+
 	gl.MatrixMode(gl.MODELVIEW)
 	gl.LoadIdentity()
+	gl.Rotatef(viewRoll, 0.0, 0.0, 1.0)
 	gl.Rotatef(viewAngles[1], 1.0, 0.0, 0.0)
 	gl.Rotatef(viewAngles[0], 0.0, 1.0, 0.0)
 	gl.Translatef(viewPos[0], viewPos[1], viewPos[2])
@@ -231,14 +415,59 @@ func refresh(win *sdl.Window) {
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 	lock.Lock()
-	for i := 0; i < len(cmds); i++ {
-		cmds[i].exec()
+	// Each walk of cmds starts from the default state: no explicit
+	// normal, and lighting off until a lighting command turns it on.
+	curNormal, curNormalSet = Vec3{}, false
+	gl.Disable(gl.LIGHTING)
+	if immediate {
+		for i := 0; i < len(cmds); i++ {
+			cmds[i].exec()
+		}
+	} else {
+		if displayListDirty {
+			compileDisplayList()
+		}
+		gl.CallList(displayList)
 	}
 	lock.Unlock()
 
+	// Capture the framebuffer before the swap: for a double-buffered
+	// context, reading GL_BACK after SwapWindow generally returns the
+	// previous frame's contents, not the one just drawn.
+	if pendingScreenshot != "" {
+		captureSync(win, pendingScreenshot)
+		pendingScreenshot = ""
+	}
+	if pendingSnapshot != "" {
+		queueCapture(win, pendingSnapshot)
+		pendingSnapshot = ""
+	}
+	if recording {
+		recordAccum += timeDelta
+		if recordAccum >= time.Second/maxRecordFPS {
+			recordAccum = 0
+			queueCapture(win, recordFramePath())
+		}
+	}
+
 	sdl.GL_SwapWindow(win)
 }
 
+// compileDisplayList walks cmds and bakes its GL calls into a single
+// display list, so refresh need only issue one gl.CallList per frame.
+// Callers must hold lock.
+func compileDisplayList() {
+	if displayList == 0 {
+		displayList = gl.GenLists(1)
+	}
+	gl.NewList(displayList, gl.COMPILE)
+	for i := 0; i < len(cmds); i++ {
+		cmds[i].exec()
+	}
+	gl.EndList()
+	displayListDirty = false
+}
+
 func validArgs(name string, args []float32, expect int) bool {
 	if len(args) != expect {
 		log.Printf("%s: expected %d arguments, found %d",
@@ -312,10 +541,116 @@ func addCmd(name string, args []float32) {
 		norm = norm.Normalize()
 		c := &planeCmd{norm, args[3]}
 		cmds = append(cmds, c)
+	case "pushmatrix":
+		if !validArgs(name, args, 0) {
+			break
+		}
+		cmds = append(cmds, &pushMatrixCmd{})
+	case "popmatrix":
+		if !validArgs(name, args, 0) {
+			break
+		}
+		cmds = append(cmds, &popMatrixCmd{})
+	case "translate":
+		if !validArgs(name, args, 3) {
+			break
+		}
+		v := Vec3{args[0], args[1], args[2]}
+		cmds = append(cmds, &translateCmd{v})
+	case "rotate":
+		if !validArgs(name, args, 4) {
+			break
+		}
+		axis := Vec3{args[1], args[2], args[3]}
+		cmds = append(cmds, &rotateCmd{args[0], axis})
+	case "scale":
+		if !validArgs(name, args, 3) {
+			break
+		}
+		v := Vec3{args[0], args[1], args[2]}
+		cmds = append(cmds, &scaleCmd{v})
+	case "bbox":
+		if !validArgs(name, args, 6) {
+			break
+		}
+		min := Vec3{args[0], args[1], args[2]}
+		max := Vec3{args[3], args[4], args[5]}
+		cmds = append(cmds, &bboxCmd{bbox{min, max}})
+	case "fit":
+		if !validArgs(name, args, 0) {
+			break
+		}
+		fitScene()
+	case "light":
+		if !validArgs(name, args, 7) {
+			break
+		}
+		idx := int(args[0])
+		if idx < 0 || idx > 7 {
+			log.Printf("light: index %d out of range, expected 0 to 7", idx)
+			break
+		}
+		pos := Vec3{args[1], args[2], args[3]}
+		col := Vec3{args[4], args[5], args[6]}
+		cmds = append(cmds, &lightCmd{idx, pos, col})
+	case "material":
+		if !validArgs(name, args, 10) {
+			break
+		}
+		amb := Vec3{args[0], args[1], args[2]}
+		dif := Vec3{args[3], args[4], args[5]}
+		spc := Vec3{args[6], args[7], args[8]}
+		cmds = append(cmds, &materialCmd{amb, dif, spc, args[9]})
+	case "normal":
+		if !validArgs(name, args, 3) {
+			break
+		}
+		n := Vec3{args[0], args[1], args[2]}
+		cmds = append(cmds, &normalCmd{n})
+	default:
+		log.Printf("%s: unknown command", name)
+	}
+
+	displayListDirty = true
+	needRefresh()
+}
+
+// addStrCmd handles commands that take a quoted string argument,
+// optionally followed by a trailing "fit" keyword, analogous to
+// addCmd for commands taking a list of numbers.
+func addStrCmd(name, arg string, fit bool) {
+	name = strings.ToLower(name)
+
+	switch name {
+	case "obj":
+		mesh, err := loadOBJ(arg)
+		if err != nil {
+			log.Printf("obj: %s", err)
+			break
+		}
+		if fit {
+			mesh.fitToUnitCube()
+		}
+		cmds = append(cmds, &objCmd{mesh})
+	case "lighting":
+		on := arg == "on"
+		if !on && arg != "off" {
+			log.Printf("lighting: expected on or off, found %q", arg)
+			break
+		}
+		cmds = append(cmds, &lightingCmd{on})
+	case "screenshot":
+		select {
+		case screenshotQueue <- arg:
+		default:
+			log.Printf("screenshot: queue full, dropping %s", arg)
+		}
+		return
 	default:
 		log.Printf("%s: unknown command", name)
 	}
 
+	displayListDirty = true
 	needRefresh()
 }
 
@@ -382,6 +717,14 @@ type polyCmd struct {
 
 func (c *polyCmd) exec() {
 	gl.Color4f(color[0], color[1], color[2], color[3])
+	if curNormalSet {
+		gl.Normal3f(curNormal[0], curNormal[1], curNormal[2])
+	} else if len(c.v) >= 3 {
+		e0 := c.v[1].Sub(c.v[0])
+		e1 := c.v[2].Sub(c.v[0])
+		n := e0.Cross(e1).Normalize()
+		gl.Normal3f(n[0], n[1], n[2])
+	}
 	if len(c.v) == 3 {
 		gl.Begin(gl.TRIANGLES)
 	} else if len(c.v) == 4 {
@@ -409,5 +752,306 @@ type planeCmd struct {
 	dist float32
 }
 
+// planeGridSize is the number of grid cells across a plane command in
+// each direction, before clipping; planeGridSize+1 lines are drawn
+// each way.
+const planeGridSize = 20
+
+// planeGridExtent is how far the unclipped grid extends from the
+// plane's closest point to the origin, along each basis vector.
+const planeGridExtent = 4
+
 func (c *planeCmd) exec() {
+	viewCube := bbox{Vec3{-1, -1, -1}, Vec3{1, 1, 1}}
+	center := c.norm.Mul(c.dist)
+
+	// Pick the world axis least parallel to norm, to derive a pair
+	// of basis vectors spanning the plane.
+	axis := Vec3{1, 0, 0}
+	if math.Abs(float64(c.norm[1])) < math.Abs(float64(c.norm[0])) &&
+		math.Abs(float64(c.norm[1])) < math.Abs(float64(c.norm[2])) {
+		axis = Vec3{0, 1, 0}
+	} else if math.Abs(float64(c.norm[2])) < math.Abs(float64(c.norm[0])) {
+		axis = Vec3{0, 0, 1}
+	}
+	u := c.norm.Cross(axis).Normalize()
+	v := c.norm.Cross(u).Normalize()
+
+	gl.LineWidth(thickness)
+	gl.Color4f(color[0], color[1], color[2], color[3])
+	gl.Begin(gl.LINES)
+	step := float32(2*planeGridExtent) / planeGridSize
+	for i := 0; i <= planeGridSize; i++ {
+		t := -float32(planeGridExtent) + float32(i)*step
+
+		a := center.Add(u.Mul(t)).Add(v.Mul(-planeGridExtent))
+		b := center.Add(u.Mul(t)).Add(v.Mul(planeGridExtent))
+		if ca, cb, ok := viewCube.Clip(a, b); ok {
+			gl.Vertex3f(ca[0], ca[1], ca[2])
+			gl.Vertex3f(cb[0], cb[1], cb[2])
+		}
+
+		a = center.Add(v.Mul(t)).Add(u.Mul(-planeGridExtent))
+		b = center.Add(v.Mul(t)).Add(u.Mul(planeGridExtent))
+		if ca, cb, ok := viewCube.Clip(a, b); ok {
+			gl.Vertex3f(ca[0], ca[1], ca[2])
+			gl.Vertex3f(cb[0], cb[1], cb[2])
+		}
+	}
+	gl.End()
+}
+
+type pushMatrixCmd struct{}
+
+func (c *pushMatrixCmd) exec() {
+	gl.PushMatrix()
+}
+
+type popMatrixCmd struct{}
+
+func (c *popMatrixCmd) exec() {
+	gl.PopMatrix()
+}
+
+type translateCmd struct {
+	v Vec3
+}
+
+func (c *translateCmd) exec() {
+	gl.Translatef(c.v[0], c.v[1], c.v[2])
+}
+
+type rotateCmd struct {
+	angle float32
+	axis  Vec3
+}
+
+func (c *rotateCmd) exec() {
+	gl.Rotatef(c.angle, c.axis[0], c.axis[1], c.axis[2])
+}
+
+type scaleCmd struct {
+	v Vec3
+}
+
+func (c *scaleCmd) exec() {
+	gl.Scalef(c.v[0], c.v[1], c.v[2])
+}
+
+// bbox is an axis-aligned bounding box.
+type bbox struct {
+	min, max Vec3
+}
+
+// Contains reports whether v lies within b, inclusive of its faces.
+func (b bbox) Contains(v Vec3) bool {
+	return v[0] >= b.min[0] && v[0] <= b.max[0] &&
+		v[1] >= b.min[1] && v[1] <= b.max[1] &&
+		v[2] >= b.min[2] && v[2] <= b.max[2]
+}
+
+// Clip clips the segment ab against b using the Liang-Barsky
+// algorithm generalized to 3D, and reports whether any part of the
+// segment survives.
+func (b bbox) Clip(a, c Vec3) (Vec3, Vec3, bool) {
+	d := c.Sub(a)
+	t0, t1 := float32(0), float32(1)
+
+	clip := func(p, q float32) bool {
+		if p == 0 {
+			return q >= 0
+		}
+		r := q / p
+		if p < 0 {
+			if r > t1 {
+				return false
+			}
+			if r > t0 {
+				t0 = r
+			}
+		} else {
+			if r < t0 {
+				return false
+			}
+			if r < t1 {
+				t1 = r
+			}
+		}
+		return true
+	}
+
+	for i := 0; i < 3; i++ {
+		if !clip(-d[i], a[i]-b.min[i]) {
+			return Vec3{}, Vec3{}, false
+		}
+		if !clip(d[i], b.max[i]-a[i]) {
+			return Vec3{}, Vec3{}, false
+		}
+	}
+
+	return a.Add(d.Mul(t0)), a.Add(d.Mul(t1)), true
+}
+
+// extend grows b to include v.
+func (b *bbox) extend(v Vec3) {
+	for i := 0; i < 3; i++ {
+		if v[i] < b.min[i] {
+			b.min[i] = v[i]
+		}
+		if v[i] > b.max[i] {
+			b.max[i] = v[i]
+		}
+	}
+}
+
+type bboxCmd struct {
+	b bbox
+}
+
+func (c *bboxCmd) exec() {
+	min, max := c.b.min, c.b.max
+	corners := [8]Vec3{
+		{min[0], min[1], min[2]}, {max[0], min[1], min[2]},
+		{max[0], max[1], min[2]}, {min[0], max[1], min[2]},
+		{min[0], min[1], max[2]}, {max[0], min[1], max[2]},
+		{max[0], max[1], max[2]}, {min[0], max[1], max[2]},
+	}
+	edges := [12][2]int{
+		{0, 1}, {1, 2}, {2, 3}, {3, 0},
+		{4, 5}, {5, 6}, {6, 7}, {7, 4},
+		{0, 4}, {1, 5}, {2, 6}, {3, 7},
+	}
+
+	gl.LineWidth(thickness)
+	gl.Color4f(color[0], color[1], color[2], color[3])
+	gl.Begin(gl.LINES)
+	for _, e := range edges {
+		a, b := corners[e[0]], corners[e[1]]
+		gl.Vertex3f(a[0], a[1], a[2])
+		gl.Vertex3f(b[0], b[1], b[2])
+	}
+	gl.End()
+}
+
+// fitScene scans all point/line/poly/bbox vertices in cmds and adjusts
+// viewPos and orthoScale so the whole scene is visible, centered in
+// the window. Callers must hold lock.
+func fitScene() {
+	b := bbox{
+		min: Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32},
+		max: Vec3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32},
+	}
+	empty := true
+
+	// Track the accumulated pushmatrix/translate/rotate/scale
+	// transform as we walk cmds, so vertices placed inside a
+	// transform block are bounded at the position they actually
+	// render at.
+	cur := Ident4()
+	stack := make([]Mat4, 0, 8)
+
+	extend := func(v Vec3) {
+		v4 := cur.Mul4x1(Vec4{v[0], v[1], v[2], 1})
+		b.extend(Vec3{v4[0], v4[1], v4[2]})
+		empty = false
+	}
+	for _, c := range cmds {
+		switch c := c.(type) {
+		case *pushMatrixCmd:
+			stack = append(stack, cur)
+		case *popMatrixCmd:
+			if len(stack) > 0 {
+				cur = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case *translateCmd:
+			cur = cur.Mul4(Translate3D(c.v[0], c.v[1], c.v[2]))
+		case *rotateCmd:
+			cur = cur.Mul4(HomogRotate3D(DegToRad(c.angle), c.axis.Normalize()))
+		case *scaleCmd:
+			cur = cur.Mul4(Scale3D(c.v[0], c.v[1], c.v[2]))
+		case *pointCmd:
+			extend(c.v)
+		case *lineCmd:
+			extend(c.a)
+			extend(c.b)
+		case *polyCmd:
+			for _, v := range c.v {
+				extend(v)
+			}
+		case *bboxCmd:
+			extend(c.b.min)
+			extend(c.b.max)
+		case *objCmd:
+			for _, v := range c.mesh.verts {
+				extend(v)
+			}
+		}
+	}
+	if empty {
+		return
+	}
+
+	center := b.min.Add(b.max).Mul(0.5)
+	viewPos = center.Mul(-1)
+
+	// Half the box's diagonal bounds its projection from any view
+	// direction, so the fit holds however the camera is rotated.
+	radius := b.max.Sub(b.min).Len() / 2
+	if radius <= 0 {
+		radius = 0.5
+	}
+	orthoScale = radius
+}
+
+type lightCmd struct {
+	index int
+	pos   Vec3
+	color Vec3
+}
+
+func (c *lightCmd) exec() {
+	light := uint32(gl.LIGHT0 + c.index)
+	pos := [4]float32{c.pos[0], c.pos[1], c.pos[2], 1}
+	col := [4]float32{c.color[0], c.color[1], c.color[2], 1}
+	gl.Lightfv(light, gl.POSITION, &pos[0])
+	gl.Lightfv(light, gl.DIFFUSE, &col[0])
+	gl.Lightfv(light, gl.SPECULAR, &col[0])
+	gl.Enable(light)
+}
+
+type materialCmd struct {
+	ambient, diffuse, specular Vec3
+	shininess                  float32
+}
+
+func (c *materialCmd) exec() {
+	amb := [4]float32{c.ambient[0], c.ambient[1], c.ambient[2], 1}
+	dif := [4]float32{c.diffuse[0], c.diffuse[1], c.diffuse[2], 1}
+	spc := [4]float32{c.specular[0], c.specular[1], c.specular[2], 1}
+	gl.Materialfv(gl.FRONT_AND_BACK, gl.AMBIENT, &amb[0])
+	gl.Materialfv(gl.FRONT_AND_BACK, gl.DIFFUSE, &dif[0])
+	gl.Materialfv(gl.FRONT_AND_BACK, gl.SPECULAR, &spc[0])
+	gl.Materialf(gl.FRONT_AND_BACK, gl.SHININESS, c.shininess)
+}
+
+type normalCmd struct {
+	n Vec3
+}
+
+func (c *normalCmd) exec() {
+	curNormal = c.n
+	curNormalSet = true
+}
+
+type lightingCmd struct {
+	on bool
+}
+
+func (c *lightingCmd) exec() {
+	if c.on {
+		gl.Enable(gl.LIGHTING)
+	} else {
+		gl.Disable(gl.LIGHTING)
+	}
 }
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	. "github.com/go-gl/mathgl/mgl32"
+)
+
+// objFace is a single polygonal face, as vertex and (optional) normal
+// indices into the parent objMesh's verts/normals slices.
+type objFace struct {
+	verts   []int
+	normals []int // empty if the face had no vn/v//vn references
+}
+
+// objMesh is a mesh loaded from a Wavefront OBJ file.
+type objMesh struct {
+	verts   []Vec3
+	normals []Vec3
+	faces   []objFace
+}
+
+// loadOBJ reads a minimal subset of the Wavefront OBJ format: v, vn and
+// f lines. vt, o, g, s, mtllib and usemtl lines are recognized and
+// ignored; anything else is skipped silently, as real-world OBJ files
+// carry other directives we don't care about.
+func loadOBJ(path string) (*objMesh, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &objMesh{}
+	var faceLines []int
+
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+
+		switch fields[0] {
+		case "v":
+			v, err := parseOBJVec3(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", path, lineNum, err)
+			}
+			m.verts = append(m.verts, v)
+		case "vn":
+			vn, err := parseOBJVec3(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", path, lineNum, err)
+			}
+			m.normals = append(m.normals, vn)
+		case "f":
+			face, err := parseOBJFace(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", path, lineNum, err)
+			}
+			m.faces = append(m.faces, face)
+			faceLines = append(faceLines, lineNum)
+		case "vt", "o", "g", "s", "mtllib", "usemtl":
+			// not supported; ignored
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, face := range m.faces {
+		for _, vi := range face.verts {
+			if vi < 0 || vi >= len(m.verts) {
+				return nil, fmt.Errorf("%s:%d: vertex index %d out of range (have %d vertices)",
+					path, faceLines[i], vi+1, len(m.verts))
+			}
+		}
+		for _, ni := range face.normals {
+			if ni < 0 || ni >= len(m.normals) {
+				return nil, fmt.Errorf("%s:%d: normal index %d out of range (have %d normals)",
+					path, faceLines[i], ni+1, len(m.normals))
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func parseOBJVec3(fields []string) (Vec3, error) {
+	if len(fields) != 3 {
+		return Vec3{}, fmt.Errorf("expected 3 components, found %d", len(fields))
+	}
+	var v Vec3
+	for i, s := range fields {
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return Vec3{}, err
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// parseOBJFace parses the vertex references of an f line, in any of
+// the v, v/vt, v/vt/vn or v//vn forms. Indices are converted from
+// OBJ's 1-based form to 0-based.
+func parseOBJFace(fields []string) (objFace, error) {
+	if len(fields) < 3 {
+		return objFace{}, fmt.Errorf("expected at least 3 vertices, found %d", len(fields))
+	}
+
+	var face objFace
+	for _, ref := range fields {
+		parts := strings.Split(ref, "/")
+
+		vi, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return objFace{}, fmt.Errorf("bad vertex index %q", parts[0])
+		}
+		face.verts = append(face.verts, vi-1)
+
+		if len(parts) == 3 && parts[2] != "" {
+			ni, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return objFace{}, fmt.Errorf("bad normal index %q", parts[2])
+			}
+			face.normals = append(face.normals, ni-1)
+		}
+	}
+	return face, nil
+}
+
+// fitToUnitCube rescales and centers m so its bounding box fits within
+// the -1..1 cube.
+func (m *objMesh) fitToUnitCube() {
+	if len(m.verts) == 0 {
+		return
+	}
+
+	min, max := m.verts[0], m.verts[0]
+	for _, v := range m.verts[1:] {
+		for i := 0; i < 3; i++ {
+			if v[i] < min[i] {
+				min[i] = v[i]
+			}
+			if v[i] > max[i] {
+				max[i] = v[i]
+			}
+		}
+	}
+
+	center := min.Add(max).Mul(0.5)
+	extent := max.Sub(min)
+	radius := float32(math.Max(float64(extent[0]), math.Max(float64(extent[1]), float64(extent[2]))))
+	if radius <= 0 {
+		return
+	}
+	scale := 2 / radius
+
+	for i, v := range m.verts {
+		m.verts[i] = v.Sub(center).Mul(scale)
+	}
+}
+
+type objCmd struct {
+	mesh *objMesh
+}
+
+func (c *objCmd) exec() {
+	gl.Color4f(color[0], color[1], color[2], color[3])
+	for _, face := range c.mesh.faces {
+		if len(face.normals) == 0 {
+			if curNormalSet {
+				gl.Normal3f(curNormal[0], curNormal[1], curNormal[2])
+			} else if len(face.verts) >= 3 {
+				v0 := c.mesh.verts[face.verts[0]]
+				e0 := c.mesh.verts[face.verts[1]].Sub(v0)
+				e1 := c.mesh.verts[face.verts[2]].Sub(v0)
+				n := e0.Cross(e1).Normalize()
+				gl.Normal3f(n[0], n[1], n[2])
+			}
+		}
+		switch len(face.verts) {
+		case 3:
+			gl.Begin(gl.TRIANGLES)
+		case 4:
+			gl.Begin(gl.QUADS)
+		default:
+			gl.Begin(gl.POLYGON)
+		}
+		for i, vi := range face.verts {
+			if i < len(face.normals) {
+				n := c.mesh.normals[face.normals[i]]
+				gl.Normal3f(n[0], n[1], n[2])
+			}
+			v := c.mesh.verts[vi]
+			gl.Vertex3f(v[0], v[1], v[2])
+		}
+		gl.End()
+
+		gl.LineWidth(thickness)
+		gl.Color4f(wireColor[0], wireColor[1], wireColor[2], wireColor[3])
+		gl.Begin(gl.LINE_LOOP)
+		for _, vi := range face.verts {
+			v := c.mesh.verts[vi]
+			gl.Vertex3f(v[0], v[1], v[2])
+		}
+		gl.End()
+
+		gl.Color4f(color[0], color[1], color[2], color[3])
+	}
+}